@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	"knative.dev/pkg/apis"
+)
+
+// RouteConditionCertificateProvisioned is the route-wide roll-up of every
+// per-tag Certificate condition (see CertificateProvisionedConditionType):
+// True only once every tag's Certificate is Ready. It's only meaningful
+// when AutoTLS is enabled; Routes that don't use it never acquire this
+// condition, so it doesn't affect their Ready computation.
+const RouteConditionCertificateProvisioned apis.ConditionType = "CertificateProvisioned"
+
+// certificateProvisionedConditionPrefix namespaces the per-tag Certificate
+// condition types so they can be told apart from RouteConditionCertificateProvisioned
+// and from each other in Status.Conditions.
+const certificateProvisionedConditionPrefix = "CertificateProvisioned:"
+
+// CertificateProvisionedConditionType returns the condition type that
+// tracks a single traffic tag's Certificate (the Route's main host uses
+// "main" in place of the empty tag, since a ConditionType can't be blank).
+// Unlike RouteConditionCertificateProvisioned, callers can read this one
+// to find out which specific tag isn't Ready yet.
+func CertificateProvisionedConditionType(tag string) apis.ConditionType {
+	return apis.ConditionType(certificateProvisionedConditionPrefix + tagOrDefault(tag))
+}
+
+// MarkCertificateProvisioned records that the Certificate for the given
+// traffic tag (the empty string for the Route's main host) is Ready.
+func (rs *RouteStatus) MarkCertificateProvisioned(tag string) {
+	routeCondSet.Manage(rs).MarkTrue(CertificateProvisionedConditionType(tag))
+}
+
+// MarkCertificateNotProvisioned records that the Certificate for the given
+// tag hasn't become Ready yet. The Ingress falls back to HTTP-only for
+// that tag's hostname until it does.
+func (rs *RouteStatus) MarkCertificateNotProvisioned(tag string) {
+	routeCondSet.Manage(rs).MarkFalse(CertificateProvisionedConditionType(tag), "CertificateNotReady",
+		fmt.Sprintf("Certificate for tag %q is not yet Ready; serving over HTTP until it is", tagOrDefault(tag)))
+}
+
+// MarkCertificateProvisionFailed records that creating or updating the
+// Certificate for the given tag returned an error.
+func (rs *RouteStatus) MarkCertificateProvisionFailed(tag string, err error) {
+	routeCondSet.Manage(rs).MarkFalse(CertificateProvisionedConditionType(tag), "CertificateProvisionFailed",
+		fmt.Sprintf("failed to provision Certificate for tag %q: %v", tagOrDefault(tag), err))
+}
+
+// MarkCertificatesProvisioned rolls every per-tag Certificate condition
+// recorded for `tags` (via MarkCertificateProvisioned /
+// MarkCertificateNotProvisioned / MarkCertificateProvisionFailed) up into
+// RouteConditionCertificateProvisioned. It's called once per reconcile,
+// after every tag in the current traffic split has been marked, so the
+// roll-up always reflects the full set rather than whichever tag was
+// reconciled most recently.
+func (rs *RouteStatus) MarkCertificatesProvisioned(tags []string) {
+	mgr := routeCondSet.Manage(rs)
+	for _, tag := range tags {
+		cond := mgr.GetCondition(CertificateProvisionedConditionType(tag))
+		if cond == nil {
+			continue
+		}
+		if !cond.IsTrue() {
+			mgr.MarkFalse(RouteConditionCertificateProvisioned, cond.Reason, cond.Message)
+			return
+		}
+	}
+	mgr.MarkTrue(RouteConditionCertificateProvisioned)
+}
+
+func tagOrDefault(tag string) string {
+	if tag == "" {
+		return "main"
+	}
+	return tag
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"knative.dev/pkg/apis"
+)
+
+// RouteConditionDomainMapped is set when one or more DomainMapping objects
+// reference this Route. It is False, rather than simply absent, when a
+// DomainMapping wanted to claim a hostname that's already claimed by
+// another Route, so the conflict is visible without digging through events.
+const RouteConditionDomainMapped apis.ConditionType = "DomainMapped"
+
+// MarkDomainClaimed records that `accepted` hostnames are now routed to
+// this Route via one or more DomainMappings. When there's nothing mapped it
+// clears any prior DomainMapped condition instead of setting one, so
+// existing Routes that don't use DomainMapping are unaffected.
+func (rs *RouteStatus) MarkDomainClaimed(accepted []string) {
+	if len(accepted) == 0 {
+		routeCondSet.Manage(rs).ClearCondition(RouteConditionDomainMapped)
+		return
+	}
+	routeCondSet.Manage(rs).MarkTrue(RouteConditionDomainMapped)
+}
+
+// MarkDomainConflicted records that `hosts` were claimed by a DomainMapping
+// targeting this Route, but some other Route already owns each of them --
+// mirroring MarkServiceNotOwned's "someone else got there first" semantics.
+// It's a no-op when `hosts` is empty, so callers can call it unconditionally
+// alongside MarkDomainClaimed.
+func (rs *RouteStatus) MarkDomainConflicted(hosts []string) {
+	if len(hosts) == 0 {
+		return
+	}
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionDomainMapped,
+		"HostConflict", fmt.Sprintf("hosts already mapped to a different Route: %s", strings.Join(hosts, ", ")))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkDomainClaimed(t *testing.T) {
+	rs := &RouteStatus{}
+
+	rs.MarkDomainClaimed([]string{"a.example.org"})
+	if !rs.GetCondition(RouteConditionDomainMapped).IsTrue() {
+		t.Error("expected DomainMapped to be True once a host is claimed")
+	}
+
+	rs.MarkDomainClaimed(nil)
+	if rs.GetCondition(RouteConditionDomainMapped) != nil {
+		t.Error("expected DomainMapped to be cleared once nothing is mapped")
+	}
+}
+
+func TestMarkDomainConflictedAggregatesHosts(t *testing.T) {
+	rs := &RouteStatus{}
+
+	rs.MarkDomainConflicted(nil)
+	if rs.GetCondition(RouteConditionDomainMapped) != nil {
+		t.Error("expected MarkDomainConflicted(nil) to be a no-op")
+	}
+
+	rs.MarkDomainClaimed([]string{"a.example.org", "b.example.org", "c.example.org"})
+	rs.MarkDomainConflicted([]string{"b.example.org", "c.example.org"})
+
+	cond := rs.GetCondition(RouteConditionDomainMapped)
+	if cond == nil || !cond.IsFalse() {
+		t.Fatal("expected DomainMapped to be False once any host conflicts")
+	}
+	if !strings.Contains(cond.Message, "b.example.org") || !strings.Contains(cond.Message, "c.example.org") {
+		t.Errorf("message %q should list every conflicting host, not just the first", cond.Message)
+	}
+}
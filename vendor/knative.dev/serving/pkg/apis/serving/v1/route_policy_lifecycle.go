@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	"knative.dev/pkg/apis"
+)
+
+// RouteConditionPolicyReady tracks whether the RoutePolicy objects
+// targeting this Route have been successfully merged into its Ingress. A
+// Route with no RoutePolicy selecting it gets this condition set True, and
+// its Ingress gets no policy annotation -- "allow all" is the default.
+const RouteConditionPolicyReady apis.ConditionType = "PolicyReady"
+
+// MarkPolicyReady records that every RoutePolicy targeting this Route
+// (if any) was merged into the Ingress without conflict.
+func (rs *RouteStatus) MarkPolicyReady() {
+	routeCondSet.Manage(rs).MarkTrue(RouteConditionPolicyReady)
+}
+
+// MarkPolicyConflict records that two or more RoutePolicy objects
+// targeting this Route disagree about the given tag, so the Ingress was
+// given a deny-all rule for that tag rather than either of their rules --
+// a conflict must fail closed, not fall back to "allow all".
+func (rs *RouteStatus) MarkPolicyConflict(tag string) {
+	routeCondSet.Manage(rs).MarkFalse(RouteConditionPolicyReady,
+		"PolicyConflict", fmt.Sprintf("multiple RoutePolicy objects conflict for tag %q", tagOrDefault(tag)))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// routePolicyCondSet is the set of conditions a RoutePolicy tracks. It has
+// a single dependent condition, so Ready and RoutePolicyConditionReady
+// always agree; Ready exists mainly so RoutePolicy satisfies the same
+// duckv1.KRShaped conventions as every other Knative Serving type.
+var routePolicyCondSet = apis.NewLivingConditionSet(RoutePolicyConditionReady)
+
+// RoutePolicyConditionReady is True once the policy has been merged into
+// every Route it targets without conflicting with another policy on the
+// same tag.
+const RoutePolicyConditionReady apis.ConditionType = apis.ConditionReady
+
+// GetConditionSet implements duckv1.KRShaped.
+func (*RoutePolicy) GetConditionSet() apis.ConditionSet {
+	return routePolicyCondSet
+}
+
+// GetStatus implements duckv1.KRShaped.
+func (rp *RoutePolicy) GetStatus() *duckv1.Status {
+	return &rp.Status.Status
+}
+
+// InitializeConditions sets the initial values to the conditions.
+func (rps *RoutePolicyStatus) InitializeConditions() {
+	routePolicyCondSet.Manage(rps).InitializeConditions()
+}
+
+// MarkReady marks the RoutePolicy as having been successfully applied to
+// every Route it targets.
+func (rps *RoutePolicyStatus) MarkReady() {
+	routePolicyCondSet.Manage(rps).MarkTrue(RoutePolicyConditionReady)
+}
+
+// MarkConflict marks the RoutePolicy as not applied because it conflicts
+// with another RoutePolicy targeting the same Route and tag.
+func (rps *RoutePolicyStatus) MarkConflict(reason, message string) {
+	routePolicyCondSet.Manage(rps).MarkFalse(RoutePolicyConditionReady, reason, message)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RoutePolicy gates which callers may reach a Route's Ingress, the way
+// eventing's EventPolicy gates delivery to a Broker or Trigger: `To`
+// selects the Route(s) the policy applies to, and `From` lists the
+// principals or network ranges that are allowed to reach them. A Route
+// with no RoutePolicy selecting it is unaffected -- "allow all" remains
+// the default so existing Routes keep working unmodified.
+type RoutePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoutePolicySpec   `json:"spec,omitempty"`
+	Status RoutePolicyStatus `json:"status,omitempty"`
+}
+
+// RoutePolicySpec is the spec for a RoutePolicy resource.
+type RoutePolicySpec struct {
+	// To selects the Route(s) this policy applies to. An empty To list
+	// selects no Routes; it is not shorthand for "all Routes".
+	To []RoutePolicyToRef `json:"to,omitempty"`
+
+	// From lists the principals allowed to reach the selected Route(s).
+	// An empty From list would silently block all traffic, so it is
+	// rejected by the validating webhook rather than accepted as
+	// "allow none".
+	From []RoutePolicyFromRef `json:"from"`
+}
+
+// RoutePolicyToRef identifies the Route (optionally scoped to one of its
+// traffic tags) a policy's From rules apply to.
+type RoutePolicyToRef struct {
+	// Name of the target Route. Required; RoutePolicy only targets
+	// Routes in its own namespace.
+	Name string `json:"name"`
+
+	// Tag scopes the policy to a single named traffic target. Empty
+	// means the policy applies to the Route's default, untagged host as
+	// well as every named tag that isn't covered by a more specific
+	// RoutePolicy.
+	Tag string `json:"tag,omitempty"`
+}
+
+// RoutePolicyFromRef describes one allowed caller. Exactly one of its
+// fields should be set; which ones are mutually exclusive is left to the
+// validating webhook rather than the type system, matching how
+// EventPolicy's From works.
+type RoutePolicyFromRef struct {
+	// CIDR allows requests whose source address falls within this
+	// range, e.g. "10.0.0.0/8".
+	CIDR string `json:"cidr,omitempty"`
+
+	// JWTIssuer allows requests bearing a JWT from this issuer. Bound
+	// to a specific tag via RoutePolicyToRef.Tag, this lets a tag
+	// require its own issuer (e.g. a "staging" tag trusting a
+	// lower-assurance IdP that "prod" wouldn't).
+	JWTIssuer string `json:"jwtIssuer,omitempty"`
+
+	// ServiceAccount allows requests authenticated as this in-cluster
+	// ServiceAccount, namespace/name.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+}
+
+// RoutePolicyStatus is the status for a RoutePolicy resource.
+type RoutePolicyStatus struct {
+	duckv1.Status `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RoutePolicyList is a list of RoutePolicy resources.
+type RoutePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []RoutePolicy `json:"items"`
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"knative.dev/pkg/apis"
+)
+
+// Validate implements apis.Validatable, and is invoked by the serving
+// validating webhook on every create/update of a RoutePolicy.
+func (rp *RoutePolicy) Validate(ctx context.Context) *apis.FieldError {
+	return rp.Spec.Validate(ctx).ViaField("spec")
+}
+
+// Validate checks the RoutePolicySpec. A RoutePolicy whose From list is
+// empty would silently allow no traffic at all to everything it selects
+// -- almost certainly not what the author intended, and surprising enough
+// that we reject it outright rather than accept "allow none" silently.
+func (rps *RoutePolicySpec) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+
+	if len(rps.To) == 0 {
+		errs = errs.Also(apis.ErrMissingField("to"))
+	}
+	for i, to := range rps.To {
+		if to.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("to", i))
+		}
+	}
+
+	if len(rps.From) == 0 {
+		errs = errs.Also(&apis.FieldError{
+			Message: "from must not be empty: an empty from list would block all traffic to the selected Route(s)",
+			Paths:   []string{"from"},
+		})
+	}
+	for i, from := range rps.From {
+		errs = errs.Also(from.validate().ViaFieldIndex("from", i))
+	}
+
+	return errs
+}
+
+func (f RoutePolicyFromRef) validate() *apis.FieldError {
+	set := 0
+	for _, v := range []string{f.CIDR, f.JWTIssuer, f.ServiceAccount} {
+		if v != "" {
+			set++
+		}
+	}
+	switch set {
+	case 0:
+		return apis.ErrMissingOneOf("cidr", "jwtIssuer", "serviceAccount")
+	case 1:
+		return nil
+	default:
+		return apis.ErrMultipleOneOf("cidr", "jwtIssuer", "serviceAccount")
+	}
+}
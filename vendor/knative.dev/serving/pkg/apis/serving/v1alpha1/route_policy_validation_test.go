@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRoutePolicySpecValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    RoutePolicySpec
+		wantErr bool
+	}{{
+		name: "valid CIDR policy",
+		spec: RoutePolicySpec{
+			To:   []RoutePolicyToRef{{Name: "my-route"}},
+			From: []RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}},
+		},
+		wantErr: false,
+	}, {
+		name: "empty from is rejected",
+		spec: RoutePolicySpec{
+			To:   []RoutePolicyToRef{{Name: "my-route"}},
+			From: []RoutePolicyFromRef{},
+		},
+		wantErr: true,
+	}, {
+		name: "missing to is rejected",
+		spec: RoutePolicySpec{
+			From: []RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}},
+		},
+		wantErr: true,
+	}, {
+		name: "to without a name is rejected",
+		spec: RoutePolicySpec{
+			To:   []RoutePolicyToRef{{Tag: "canary"}},
+			From: []RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}},
+		},
+		wantErr: true,
+	}, {
+		name: "from with no selector set is rejected",
+		spec: RoutePolicySpec{
+			To:   []RoutePolicyToRef{{Name: "my-route"}},
+			From: []RoutePolicyFromRef{{}},
+		},
+		wantErr: true,
+	}, {
+		name: "from with multiple selectors set is rejected",
+		spec: RoutePolicySpec{
+			To:   []RoutePolicyToRef{{Name: "my-route"}},
+			From: []RoutePolicyFromRef{{CIDR: "10.0.0.0/8", JWTIssuer: "https://issuer.example.com"}},
+		},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.spec.Validate(context.Background())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, test.wantErr)
+			}
+		})
+	}
+}
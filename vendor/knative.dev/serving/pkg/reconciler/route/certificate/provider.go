@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certificate reconciles one netv1alpha1.Certificate per traffic
+// tag hostname for a Route, and aggregates the results into the TLS and
+// HTTP-01 challenge material that reconcileIngress needs. It's deliberately
+// decoupled from any one certificate issuer: net-certmanager, a
+// self-signed dev issuer, or an external Vault-backed issuer can all be
+// plugged in by implementing Provider.
+package certificate
+
+import (
+	"context"
+
+	"knative.dev/pkg/kmeta"
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+)
+
+// Provider issues and tears down Certificates on behalf of the route
+// reconciler. Implementations are looked up by name from the
+// `certificate-provider` key of the config-network ConfigMap so that a
+// cluster operator can swap issuers without a binary rebuild.
+type Provider interface {
+	// Ensure creates or updates the Certificate for dnsNames, owned by
+	// owner, and returns its current state. It must be safe to call
+	// repeatedly with the same arguments (e.g. on every Route
+	// reconciliation) without creating duplicate Certificates.
+	Ensure(ctx context.Context, owner kmeta.OwnerRefable, dnsNames []string) (*netv1alpha1.Certificate, error)
+
+	// Delete removes the named Certificate, if one exists. It must not
+	// return an error when the Certificate is already gone.
+	Delete(ctx context.Context, namespace, name string) error
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// Result is what a Reconcile pass contributes to the Ingress the route
+// reconciler is about to build.
+type Result struct {
+	// TLS holds one entry per tag whose Certificate is Ready.
+	TLS []netv1alpha1.IngressTLS
+
+	// HTTP01Challenges aggregates the pending ACME challenges of every
+	// tag whose Certificate isn't Ready yet, so the challenge path stays
+	// reachable while the cert provisions.
+	HTTP01Challenges []netv1alpha1.HTTP01Challenge
+
+	// HTTPOnlyHosts is the set of hostnames whose Certificate isn't
+	// Ready yet. reconcileIngress should keep serving these over HTTP
+	// instead of redirecting to HTTPS, so the fallback above is
+	// actually reachable by the ACME solver.
+	HTTPOnlyHosts sets.String
+}
+
+// Reconciler provisions per-tag Certificates for a Route through a
+// pluggable Provider.
+type Reconciler struct {
+	Provider Provider
+}
+
+// Reconcile ensures one Certificate per traffic tag hostname (the main
+// host, plus "<tag>-<route>.<domain>" for each named tag in tc.Targets),
+// and aggregates the outcome into a Result. It never fails the Route
+// reconciliation solely because a Certificate isn't Ready yet -- that case
+// is reported through HTTPOnlyHosts, the per-tag
+// CertificateProvisionedConditionType conditions, and their roll-up
+// RouteConditionCertificateProvisioned instead.
+func (r *Reconciler) Reconcile(ctx context.Context, route *v1.Route, tc *traffic.Config) (*Result, error) {
+	result := &Result{HTTPOnlyHosts: sets.NewString()}
+
+	tags := tagNames(tc)
+	for _, tag := range tags {
+		hostnames := dnsNames(route, tag)
+		if len(hostnames) == 0 {
+			continue
+		}
+
+		cert, err := r.Provider.Ensure(ctx, route, hostnames)
+		if err != nil {
+			route.Status.MarkCertificateProvisionFailed(tag, err)
+			route.Status.MarkCertificatesProvisioned(tags)
+			return nil, fmt.Errorf("failed to ensure Certificate for tag %q: %w", tag, err)
+		}
+
+		if cert.Status.IsReady() {
+			route.Status.MarkCertificateProvisioned(tag)
+			result.TLS = append(result.TLS, netv1alpha1.IngressTLS{
+				Hosts:           hostnames,
+				SecretName:      cert.Spec.SecretName,
+				SecretNamespace: cert.Namespace,
+			})
+			continue
+		}
+
+		route.Status.MarkCertificateNotProvisioned(tag)
+		result.HTTP01Challenges = append(result.HTTP01Challenges, cert.Status.HTTP01Challenges...)
+		result.HTTPOnlyHosts.Insert(hostnames...)
+	}
+
+	route.Status.MarkCertificatesProvisioned(tags)
+	return result, nil
+}
+
+// tagNames returns the tags with traffic, the empty string tag (the main
+// host) always sorted first.
+func tagNames(tc *traffic.Config) []string {
+	tags := make([]string, 0, len(tc.Targets))
+	for tag := range tc.Targets {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i] == "" {
+			return true
+		}
+		if tags[j] == "" {
+			return false
+		}
+		return tags[i] < tags[j]
+	})
+	return tags
+}
+
+// dnsNames returns the hostname(s) a given tag's Certificate should cover:
+// the Route's main domain for the empty/default tag, or
+// "<tag>-<route>.<domain>" for a named tag.
+func dnsNames(route *v1.Route, tag string) []string {
+	if route.Status.URL == nil || route.Status.URL.Host == "" {
+		return nil
+	}
+	if tag == "" {
+		return []string{route.Status.URL.Host}
+	}
+	return []string{tag + "-" + route.Status.URL.Host}
+}
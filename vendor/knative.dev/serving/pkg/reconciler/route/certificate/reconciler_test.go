@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/kmeta"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// fakeProvider is a Provider that returns pre-baked Certificates (or an
+// error) per dnsNames, so Reconcile can be exercised without a real
+// issuer or any Kubernetes client.
+type fakeProvider struct {
+	certsByHost map[string]*netv1alpha1.Certificate
+	errsByHost  map[string]error
+}
+
+func (f *fakeProvider) Ensure(ctx context.Context, owner kmeta.OwnerRefable, dnsNames []string) (*netv1alpha1.Certificate, error) {
+	key := dnsNames[0]
+	if err := f.errsByHost[key]; err != nil {
+		return nil, err
+	}
+	return f.certsByHost[key], nil
+}
+
+func (f *fakeProvider) Delete(ctx context.Context, namespace, name string) error {
+	return nil
+}
+
+func readyCert(host string) *netv1alpha1.Certificate {
+	return &netv1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+		Spec:       netv1alpha1.CertificateSpec{SecretName: host + "-cert"},
+		Status: netv1alpha1.CertificateStatus{
+			Status: duckv1.Status{
+				Conditions: duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}},
+			},
+		},
+	}
+}
+
+func notReadyCert(host string) *netv1alpha1.Certificate {
+	return &netv1alpha1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+		Status: netv1alpha1.CertificateStatus{
+			HTTP01Challenges: []netv1alpha1.HTTP01Challenge{{ServiceName: host + "-solver"}},
+		},
+	}
+}
+
+func routeWithTags(host string, tags ...string) (*v1.Route, *traffic.Config) {
+	route := &v1.Route{}
+	route.Status.URL = &apis.URL{Scheme: "https", Host: host}
+	targets := map[string]traffic.RevisionTargets{}
+	for _, tag := range tags {
+		targets[tag] = nil
+	}
+	return route, &traffic.Config{Targets: targets}
+}
+
+func TestTagNamesDefaultFirst(t *testing.T) {
+	tc := &traffic.Config{Targets: map[string]traffic.RevisionTargets{
+		"canary":  nil,
+		"":        nil,
+		"staging": nil,
+	}}
+
+	got := tagNames(tc)
+	want := []string{"", "canary", "staging"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("tagNames (-want +got):\n%s", diff)
+	}
+}
+
+func TestDNSNames(t *testing.T) {
+	route := &v1.Route{}
+	route.Status.URL = &apis.URL{Scheme: "https", Host: "my-route.default.example.com"}
+
+	if got, want := dnsNames(route, ""), []string{"my-route.default.example.com"}; !cmp.Equal(got, want) {
+		t.Errorf("dnsNames(%q) = %v, want %v", "", got, want)
+	}
+	if got, want := dnsNames(route, "canary"), []string{"canary-my-route.default.example.com"}; !cmp.Equal(got, want) {
+		t.Errorf("dnsNames(%q) = %v, want %v", "canary", got, want)
+	}
+}
+
+func TestDNSNamesNoURL(t *testing.T) {
+	route := &v1.Route{}
+	if got := dnsNames(route, ""); got != nil {
+		t.Errorf("dnsNames with no Status.URL = %v, want nil", got)
+	}
+}
+
+func TestReconcileAllTagsReady(t *testing.T) {
+	route, tc := routeWithTags("my-route.default.example.com", "", "canary")
+	r := &Reconciler{Provider: &fakeProvider{certsByHost: map[string]*netv1alpha1.Certificate{
+		"my-route.default.example.com":        readyCert("my-route.default.example.com"),
+		"canary-my-route.default.example.com": readyCert("canary-my-route.default.example.com"),
+	}}}
+
+	result, err := r.Reconcile(context.Background(), route, tc)
+	if err != nil {
+		t.Fatalf("Reconcile() returned error: %v", err)
+	}
+	if got, want := len(result.TLS), 2; got != want {
+		t.Errorf("len(TLS) = %d, want %d", got, want)
+	}
+	if got := result.HTTPOnlyHosts.Len(); got != 0 {
+		t.Errorf("HTTPOnlyHosts.Len() = %d, want 0", got)
+	}
+	if !route.Status.GetCondition(v1.RouteConditionCertificateProvisioned).IsTrue() {
+		t.Error("expected CertificateProvisioned to be True once every tag is Ready")
+	}
+}
+
+func TestReconcileFallsBackToHTTPOnlyForNotReadyTag(t *testing.T) {
+	route, tc := routeWithTags("my-route.default.example.com", "", "canary")
+	r := &Reconciler{Provider: &fakeProvider{certsByHost: map[string]*netv1alpha1.Certificate{
+		"my-route.default.example.com":        readyCert("my-route.default.example.com"),
+		"canary-my-route.default.example.com": notReadyCert("canary-my-route.default.example.com"),
+	}}}
+
+	result, err := r.Reconcile(context.Background(), route, tc)
+	if err != nil {
+		t.Fatalf("Reconcile() returned error: %v", err)
+	}
+	if got, want := len(result.TLS), 1; got != want {
+		t.Errorf("len(TLS) = %d, want %d (only the Ready tag)", got, want)
+	}
+	if !result.HTTPOnlyHosts.Has("canary-my-route.default.example.com") {
+		t.Error("expected the not-Ready tag's host in HTTPOnlyHosts")
+	}
+	if len(result.HTTP01Challenges) != 1 {
+		t.Errorf("len(HTTP01Challenges) = %d, want 1", len(result.HTTP01Challenges))
+	}
+
+	// The route-wide condition reflects the one tag that isn't Ready...
+	if route.Status.GetCondition(v1.RouteConditionCertificateProvisioned).IsTrue() {
+		t.Error("expected CertificateProvisioned to be False while a tag is still provisioning")
+	}
+	// ...but the per-tag condition still distinguishes which tag it was.
+	mainCond := route.Status.GetCondition(v1.CertificateProvisionedConditionType(""))
+	if mainCond == nil || !mainCond.IsTrue() {
+		t.Error("expected the main tag's own condition to stay True")
+	}
+	canaryCond := route.Status.GetCondition(v1.CertificateProvisionedConditionType("canary"))
+	if canaryCond == nil || !canaryCond.IsFalse() {
+		t.Error("expected the canary tag's own condition to be False")
+	}
+}
+
+func TestReconcileProviderError(t *testing.T) {
+	route, tc := routeWithTags("my-route.default.example.com", "")
+	wantErr := errors.New("boom")
+	r := &Reconciler{Provider: &fakeProvider{errsByHost: map[string]error{
+		"my-route.default.example.com": wantErr,
+	}}}
+
+	if _, err := r.Reconcile(context.Background(), route, tc); err == nil {
+		t.Fatal("expected Reconcile to return an error")
+	}
+	if route.Status.GetCondition(v1.RouteConditionCertificateProvisioned).IsTrue() {
+		t.Error("expected CertificateProvisioned to be False after a provisioning error")
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/configuration/config"
+	"knative.dev/serving/pkg/reconciler/route/certificate"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// reconcileCertificates provisions per-tag TLS for r when AutoTLS is
+// enabled, via c.certificates (a *certificate.Reconciler wired up at
+// controller construction time with whichever certificate.Provider the
+// cluster is configured to use). It's a deliberate no-op otherwise, so
+// clusters that don't enable AutoTLS pay no cost and Routes behave
+// exactly as before this feature existed.
+func (c *Reconciler) reconcileCertificates(ctx context.Context, r *v1.Route, tc *traffic.Config) (*certificate.Result, error) {
+	if !config.FromContextOrDefaults(ctx).Features.AutoTLS {
+		return nil, nil
+	}
+	return c.certificates.Reconcile(ctx, r, tc)
+}
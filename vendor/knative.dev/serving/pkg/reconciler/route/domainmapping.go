@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/network"
+	"knative.dev/serving/pkg/apis/serving"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/reconciler/route/resources/names"
+)
+
+// DomainMapped is the set of hostnames that one or more DomainMapping
+// objects have asked to be routed to a Route, together with whatever TLS
+// material each mapping supplied for its host. It's assembled by the
+// caller (ReconcileKind) from the DomainMapping informer's index before
+// reconcilePlaceholderServices and reconcileIngress are called, so that
+// this file never has to reach across reconcilers to read DomainMappings
+// directly.
+type DomainMapped struct {
+	Accepted    []string
+	Conflicting []string
+	TLS         []netv1alpha1.IngressTLS
+}
+
+// reconcileDomainMappedServices ensures an ExternalName Service exists for
+// every accepted DomainMapping hostname, so that in-cluster clients can
+// resolve the mapped host the same way they'd resolve the Route's own
+// hostname. Unlike the per-tag placeholder Services, these are keyed by
+// (sanitized) hostname rather than by tag, since a mapped host has no tag
+// of its own.
+func (c *Reconciler) reconcileDomainMappedServices(ctx context.Context, route *v1.Route, mapped DomainMapped) ([]*corev1.Service, error) {
+	recorder := controller.GetEventRecorder(ctx)
+	ns := route.Namespace
+
+	target := fmt.Sprintf("%s.%s.svc.%s", names.K8sService(route), ns, network.GetClusterDomainName())
+
+	services := make([]*corev1.Service, 0, len(mapped.Accepted))
+	var ownershipConflicts []string
+	for _, host := range mapped.Accepted {
+		name := domainMappingServiceName(route, host)
+		desired := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels: map[string]string{
+					serving.RouteLabelKey: route.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(route)},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: target,
+			},
+		}
+
+		svc, err := c.serviceLister.Services(ns).Get(name)
+		if apierrs.IsNotFound(err) {
+			svc, err = c.kubeclient.CoreV1().Services(ns).Create(ctx, desired, metav1.CreateOptions{})
+			if err != nil {
+				recorder.Eventf(route, corev1.EventTypeWarning, "CreationFailed",
+					"Failed to create domain mapping service %q: %v", name, err)
+				return nil, fmt.Errorf("failed to create domain mapping service: %w", err)
+			}
+			recorder.Eventf(route, corev1.EventTypeNormal, "Created", "Created domain mapping service %q", name)
+		} else if err != nil {
+			return nil, err
+		} else if !metav1.IsControlledBy(svc, route) {
+			// Keep reconciling the other accepted hosts -- one host
+			// losing a Service-ownership race shouldn't stall the rest.
+			ownershipConflicts = append(ownershipConflicts, host)
+			continue
+		}
+
+		services = append(services, svc)
+	}
+
+	if len(ownershipConflicts) > 0 {
+		route.Status.MarkDomainConflicted(ownershipConflicts)
+		return services, fmt.Errorf("route %q does not own the Service for host(s): %s", route.Name, strings.Join(ownershipConflicts, ", "))
+	}
+
+	return services, nil
+}
+
+// appendDomainMappingRules mutates `desired` in place, adding an
+// IngressRule for each accepted DomainMapping hostname that routes
+// identically to the Route's own rule(s), and merges any per-mapping TLS
+// material into the Ingress's TLS slice.
+func appendDomainMappingRules(desired *netv1alpha1.Ingress, mapped DomainMapped) {
+	if len(mapped.Accepted) == 0 || len(desired.Spec.Rules) == 0 {
+		return
+	}
+
+	// All of the Route's own rules serve the same backend split; splice
+	// the mapped hostnames onto the first one.
+	base := desired.Spec.Rules[0]
+	hosts := append([]string(nil), mapped.Accepted...)
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		rule := *base.DeepCopy()
+		rule.Hosts = []string{host}
+		desired.Spec.Rules = append(desired.Spec.Rules, rule)
+	}
+
+	desired.Spec.TLS = append(desired.Spec.TLS, mapped.TLS...)
+}
+
+// domainMappingServiceName derives a deterministic, valid Service name
+// from the Route and the mapped hostname, so repeated reconciliations of
+// the same mapping find the same Service instead of creating duplicates.
+func domainMappingServiceName(route *v1.Route, host string) string {
+	sanitized := strings.ReplaceAll(host, ".", "-")
+	return kmeta.ChildName(route.Name+"-", sanitized)
+}
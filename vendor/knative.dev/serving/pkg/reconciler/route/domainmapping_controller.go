@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/controller"
+	"knative.dev/serving/pkg/apis/serving/v1beta1"
+)
+
+// domainMappingByRouteIndex is the name under which the DomainMapping
+// informer's cache.Indexer keys objects by the Route they target (its
+// spec.ref), so the caller that assembles DomainMapped can look up "every
+// DomainMapping claiming this Route" without listing the whole cluster.
+const domainMappingByRouteIndex = "byRoute"
+
+// IndexDomainMappingByRoute is registered as a cache.Indexer on the
+// DomainMapping informer under domainMappingByRouteIndex. A DomainMapping
+// targets exactly one Route via spec.ref, so it returns at most one key,
+// namespace-scoped the same way IndexRoutePolicyByRoute is.
+func IndexDomainMappingByRoute(obj interface{}) ([]string, error) {
+	dm, ok := obj.(*v1beta1.DomainMapping)
+	if !ok || dm.Spec.Ref.Name == "" {
+		return nil, nil
+	}
+	ns := dm.Spec.Ref.Namespace
+	if ns == "" {
+		ns = dm.Namespace
+	}
+	return []string{ns + "/" + dm.Spec.Ref.Name}, nil
+}
+
+// NewDomainMappingEventHandler returns a cache.ResourceEventHandler to
+// register against the DomainMapping informer. A DomainMapping is never
+// owned by the Route it maps to -- ownership would be backwards, since
+// many DomainMappings can reference one Route -- so, as with RoutePolicy,
+// changes are fanned out by reading spec.ref rather than by owner ref.
+func NewDomainMappingEventHandler(impl *controller.Impl) cache.ResourceEventHandler {
+	return controller.HandleAll(func(obj interface{}) {
+		dm, ok := obj.(*v1beta1.DomainMapping)
+		if !ok || dm.Spec.Ref.Name == "" {
+			return
+		}
+		ns := dm.Spec.Ref.Namespace
+		if ns == "" {
+			ns = dm.Namespace
+		}
+		impl.EnqueueKey(types.NamespacedName{Namespace: ns, Name: dm.Spec.Ref.Name})
+	})
+}
+
+// RegisterDomainMappingHandlers adds domainMappingByRouteIndex and
+// NewDomainMappingEventHandler to the DomainMapping informer passed in.
+// NewController calls this, the same way RegisterRoutePolicyHandlers gets
+// called for the RoutePolicy informer, once it has obtained the
+// DomainMapping informer from the injection context.
+func RegisterDomainMappingHandlers(impl *controller.Impl, domainMappingInformer cache.SharedIndexInformer) {
+	domainMappingInformer.AddIndexers(cache.Indexers{domainMappingByRouteIndex: IndexDomainMappingByRoute})
+	domainMappingInformer.AddEventHandler(NewDomainMappingEventHandler(impl))
+}
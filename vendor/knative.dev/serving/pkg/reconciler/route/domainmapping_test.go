@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func TestAppendDomainMappingRules(t *testing.T) {
+	desired := &netv1alpha1.Ingress{
+		Spec: netv1alpha1.IngressSpec{
+			Rules: []netv1alpha1.IngressRule{{
+				Hosts: []string{"my-route.default.example.com"},
+				HTTP:  &netv1alpha1.HTTPIngressRuleValue{},
+			}},
+		},
+	}
+	mapped := DomainMapped{
+		Accepted: []string{"b.example.org", "a.example.org"},
+		TLS:      []netv1alpha1.IngressTLS{{Hosts: []string{"a.example.org"}}},
+	}
+
+	appendDomainMappingRules(desired, mapped)
+
+	if got, want := len(desired.Spec.Rules), 3; got != want {
+		t.Fatalf("len(Rules) = %d, want %d", got, want)
+	}
+	// Mapped hosts are appended in sorted order after the Route's own rule.
+	if got, want := desired.Spec.Rules[1].Hosts[0], "a.example.org"; got != want {
+		t.Errorf("Rules[1].Hosts[0] = %q, want %q", got, want)
+	}
+	if got, want := desired.Spec.Rules[2].Hosts[0], "b.example.org"; got != want {
+		t.Errorf("Rules[2].Hosts[0] = %q, want %q", got, want)
+	}
+	if got, want := len(desired.Spec.TLS), 1; got != want {
+		t.Errorf("len(TLS) = %d, want %d", got, want)
+	}
+}
+
+func TestAppendDomainMappingRulesNoop(t *testing.T) {
+	desired := &netv1alpha1.Ingress{
+		Spec: netv1alpha1.IngressSpec{
+			Rules: []netv1alpha1.IngressRule{{Hosts: []string{"my-route.default.example.com"}}},
+		},
+	}
+	appendDomainMappingRules(desired, DomainMapped{})
+
+	if got, want := len(desired.Spec.Rules), 1; got != want {
+		t.Errorf("len(Rules) = %d, want %d (no mappings, no change)", got, want)
+	}
+}
+
+func TestDomainMappingServiceName(t *testing.T) {
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+
+	got := domainMappingServiceName(route, "foo.example.com")
+	if got == "" {
+		t.Fatal("domainMappingServiceName returned an empty name")
+	}
+	if got2 := domainMappingServiceName(route, "foo.example.com"); got != got2 {
+		t.Errorf("domainMappingServiceName is not deterministic: %q != %q", got, got2)
+	}
+	if got3 := domainMappingServiceName(route, "bar.example.com"); got == got3 {
+		t.Errorf("domainMappingServiceName collided for different hosts: %q", got)
+	}
+}
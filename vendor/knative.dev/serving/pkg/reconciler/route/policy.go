@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// PolicyAnnotationKey holds the JSON-encoded, merged access policy for a
+// Route's Ingress: which CIDRs, JWT issuers, or ServiceAccounts may reach
+// each of its tags. It's only ever set when at least one RoutePolicy
+// selects the Route; its absence means "allow all", preserving existing
+// behavior for Routes that don't use RoutePolicy.
+const PolicyAnnotationKey = "networking.knative.dev/route-policy"
+
+// AllowedSourcesAnnotationKey mirrors the default tag's CIDR allowlist in
+// a simpler, ingress-gateway-friendly form (a comma-separated list)
+// alongside the full PolicyAnnotationKey payload.
+const AllowedSourcesAnnotationKey = "networking.knative.dev/allowed-sources"
+
+// policyRule is the merged, per-tag access rule stored in
+// PolicyAnnotationKey.
+type policyRule struct {
+	Tag             string   `json:"tag,omitempty"`
+	CIDRs           []string `json:"cidrs,omitempty"`
+	JWTIssuers      []string `json:"jwtIssuers,omitempty"`
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+
+	// Deny, when true, blocks all traffic for Tag regardless of the
+	// allow fields above (which are left unset). It's only ever set when
+	// two RoutePolicy objects conflict on the same tag: a conflict must
+	// fail closed, not silently widen access back to "allow all".
+	Deny bool `json:"deny,omitempty"`
+}
+
+// reconcilePolicy merges every RoutePolicy in `policies` that targets `r`
+// into desired's annotations, and records the result (or any conflict) on
+// the Route's status. `policies` is expected to already be filtered down
+// to the ones indexed against r's namespace/name by the caller (via the
+// RoutePolicy informer's "spec.to.ref" index) -- this function doesn't
+// itself know how to list them. `tc` supplies the Route's current named
+// tags so a blank-Tag (catch-all) policy can be applied to every tag that
+// doesn't have its own more specific RoutePolicy, per RoutePolicyToRef.Tag.
+//
+// Two RoutePolicy objects conflict if they target the same (Route, tag)
+// pair: RoutePolicy rules for a given tag are meant to live in exactly one
+// object, so that reading any one RoutePolicy tells you the whole story
+// for its tag(s). When a conflict is found, the tag gets an explicit
+// deny-all rule -- never "allow all" -- and the Route's PolicyReady
+// condition goes False.
+func reconcilePolicy(ctx context.Context, r *v1.Route, tc *traffic.Config, policies []*v1alpha1.RoutePolicy, desired *netv1alpha1.Ingress) {
+	if len(policies) == 0 {
+		// No policy selects this Route: preserve "allow all" by leaving
+		// any previous policy annotations untouched -- resources.MakeIngress
+		// builds desired.Annotations fresh each time, so there's nothing
+		// to clear here.
+		r.Status.MarkPolicyReady()
+		return
+	}
+
+	byTag := map[string][]*v1alpha1.RoutePolicy{}
+	for _, p := range policies {
+		for _, to := range p.Spec.To {
+			if to.Name != r.Name {
+				continue
+			}
+			byTag[to.Tag] = append(byTag[to.Tag], p)
+		}
+	}
+
+	// Every tag that needs a rule: one for each tag an explicit policy
+	// targets, plus -- if a catch-all (blank Tag) policy exists -- every
+	// named tag currently carrying traffic, so the catch-all actually
+	// reaches tags that have no RoutePolicy of their own.
+	tagSet := map[string]bool{}
+	for tag := range byTag {
+		tagSet[tag] = true
+	}
+	if catchAll := byTag[""]; len(catchAll) > 0 && tc != nil {
+		for tag := range tc.Targets {
+			tagSet[tag] = true
+		}
+	}
+	tags := make([]string, 0, len(tagSet))
+	for tag := range tagSet {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var rules []policyRule
+	conflicted := false
+	for _, tag := range tags {
+		switch ps := byTag[tag]; {
+		case len(ps) > 1:
+			r.Status.MarkPolicyConflict(tag)
+			conflicted = true
+			rules = append(rules, policyRule{Tag: tag, Deny: true})
+		case len(ps) == 1:
+			rules = append(rules, mergeRule(tag, ps[0]))
+		case tag != "" && len(byTag[""]) == 1:
+			// No tag-specific policy: fall back to the catch-all.
+			rules = append(rules, mergeRule(tag, byTag[""][0]))
+		case tag != "" && len(byTag[""]) > 1:
+			// The catch-all itself conflicts, and this tag has no more
+			// specific policy to fall back on -- deny it too.
+			rules = append(rules, policyRule{Tag: tag, Deny: true})
+		}
+	}
+
+	if len(rules) == 0 {
+		if !conflicted {
+			r.Status.MarkPolicyReady()
+		}
+		return
+	}
+
+	payload, err := json.Marshal(rules)
+	if err != nil {
+		// Marshaling a slice of plain structs cannot fail; this is
+		// only here to satisfy vet/err-check linting.
+		return
+	}
+	desired.Annotations = withAnnotation(desired.Annotations, PolicyAnnotationKey, string(payload))
+	desired.Annotations = withAnnotation(desired.Annotations, PolicyAnnotationKey+"-checksum", checksum(payload))
+
+	for _, rule := range rules {
+		if rule.Tag == "" && len(rule.CIDRs) > 0 {
+			desired.Annotations = withAnnotation(desired.Annotations, AllowedSourcesAnnotationKey, strings.Join(rule.CIDRs, ","))
+		}
+	}
+
+	if !conflicted {
+		r.Status.MarkPolicyReady()
+	}
+}
+
+func mergeRule(tag string, p *v1alpha1.RoutePolicy) policyRule {
+	rule := policyRule{Tag: tag}
+	for _, from := range p.Spec.From {
+		switch {
+		case from.CIDR != "":
+			rule.CIDRs = append(rule.CIDRs, from.CIDR)
+		case from.JWTIssuer != "":
+			rule.JWTIssuers = append(rule.JWTIssuers, from.JWTIssuer)
+		case from.ServiceAccount != "":
+			rule.ServiceAccounts = append(rule.ServiceAccounts, from.ServiceAccount)
+		}
+	}
+	sort.Strings(rule.CIDRs)
+	sort.Strings(rule.JWTIssuers)
+	sort.Strings(rule.ServiceAccounts)
+	return rule
+}
+
+func checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+func withAnnotation(annotations map[string]string, key, value string) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[key] = value
+	return annotations
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"knative.dev/pkg/controller"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+)
+
+// routePolicyByRouteIndex is the name under which the RoutePolicy
+// informer's cache.Indexer keys objects by the Route(s) they target, so
+// reconcilePolicy's caller can look up "every RoutePolicy targeting this
+// Route" in O(1) instead of listing and filtering the whole namespace.
+const routePolicyByRouteIndex = "byRoute"
+
+// IndexRoutePolicyByRoute is registered as a cache.Indexer on the
+// RoutePolicy informer under routePolicyByRouteIndex. It returns one index
+// value per distinct Route named in the policy's spec.to, namespace-scoped
+// so a RoutePolicy can't accidentally match a same-named Route elsewhere.
+func IndexRoutePolicyByRoute(obj interface{}) ([]string, error) {
+	p, ok := obj.(*v1alpha1.RoutePolicy)
+	if !ok {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(p.Spec.To))
+	keys := make([]string, 0, len(p.Spec.To))
+	for _, to := range p.Spec.To {
+		key := p.Namespace + "/" + to.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// NewRoutePolicyEventHandler returns a cache.ResourceEventHandler to
+// register against the RoutePolicy informer. RoutePolicy has no owner
+// reference to the Route(s) it targets (a Route is named, not owned, by
+// spec.to), so this enqueues those Routes directly rather than relying on
+// controller.FilterController -- the same reason EventPolicy's controller
+// enqueues its target Brokers/Triggers by hand instead of by owner ref.
+func NewRoutePolicyEventHandler(impl *controller.Impl) cache.ResourceEventHandler {
+	return controller.HandleAll(func(obj interface{}) {
+		p, ok := obj.(*v1alpha1.RoutePolicy)
+		if !ok {
+			return
+		}
+		for _, to := range p.Spec.To {
+			impl.EnqueueKey(types.NamespacedName{Namespace: p.Namespace, Name: to.Name})
+		}
+	})
+}
+
+// RegisterRoutePolicyHandlers adds routePolicyByRouteIndex and
+// NewRoutePolicyEventHandler to the RoutePolicy informer passed in.
+// NewController calls this, alongside the equivalent calls it already
+// makes for the Route, Ingress, and Certificate informers, once it has
+// obtained the RoutePolicy informer from the injection context -- it's
+// pulled out to its own function only because the informer/indexer/
+// handler trio is specific to this CRD and easiest to unit test in
+// isolation from the rest of NewController's wiring.
+func RegisterRoutePolicyHandlers(impl *controller.Impl, routePolicyInformer cache.SharedIndexInformer) {
+	routePolicyInformer.AddIndexers(cache.Indexers{routePolicyByRouteIndex: IndexRoutePolicyByRoute})
+	routePolicyInformer.AddEventHandler(NewRoutePolicyEventHandler(impl))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+func TestReconcilePolicyDefaultAllowAll(t *testing.T) {
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+	desired := &netv1alpha1.Ingress{}
+
+	reconcilePolicy(context.Background(), route, nil, nil, desired)
+
+	if _, ok := desired.Annotations[PolicyAnnotationKey]; ok {
+		t.Error("expected no policy annotation when no RoutePolicy selects the Route")
+	}
+	if !route.Status.GetCondition(v1.RouteConditionPolicyReady).IsTrue() {
+		t.Error("expected PolicyReady to be True when no RoutePolicy applies")
+	}
+}
+
+func TestReconcilePolicyMerge(t *testing.T) {
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+	desired := &netv1alpha1.Ingress{}
+	policies := []*v1alpha1.RoutePolicy{{
+		Spec: v1alpha1.RoutePolicySpec{
+			To:   []v1alpha1.RoutePolicyToRef{{Name: "my-route"}},
+			From: []v1alpha1.RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}, {CIDR: "192.168.0.0/16"}},
+		},
+	}}
+
+	reconcilePolicy(context.Background(), route, nil, policies, desired)
+
+	payload, ok := desired.Annotations[PolicyAnnotationKey]
+	if !ok {
+		t.Fatal("expected a policy annotation to be set")
+	}
+	if !strings.Contains(payload, "10.0.0.0/8") || !strings.Contains(payload, "192.168.0.0/16") {
+		t.Errorf("policy annotation %q missing expected CIDRs", payload)
+	}
+	if got, want := desired.Annotations[AllowedSourcesAnnotationKey], "10.0.0.0/8,192.168.0.0/16"; got != want {
+		t.Errorf("AllowedSourcesAnnotationKey = %q, want %q", got, want)
+	}
+	if !route.Status.GetCondition(v1.RouteConditionPolicyReady).IsTrue() {
+		t.Error("expected PolicyReady to be True after a successful merge")
+	}
+}
+
+func TestReconcilePolicyConflict(t *testing.T) {
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+	desired := &netv1alpha1.Ingress{}
+	policies := []*v1alpha1.RoutePolicy{
+		{Spec: v1alpha1.RoutePolicySpec{
+			To:   []v1alpha1.RoutePolicyToRef{{Name: "my-route"}},
+			From: []v1alpha1.RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}},
+		}},
+		{Spec: v1alpha1.RoutePolicySpec{
+			To:   []v1alpha1.RoutePolicyToRef{{Name: "my-route"}},
+			From: []v1alpha1.RoutePolicyFromRef{{CIDR: "192.168.0.0/16"}},
+		}},
+	}
+
+	reconcilePolicy(context.Background(), route, nil, policies, desired)
+
+	payload, ok := desired.Annotations[PolicyAnnotationKey]
+	if !ok {
+		t.Fatal("expected a deny-all policy annotation even though policies conflict")
+	}
+	if !strings.Contains(payload, `"deny":true`) {
+		t.Errorf("policy annotation %q should deny the conflicting tag, not fall back to allow-all", payload)
+	}
+	if strings.Contains(payload, "10.0.0.0/8") || strings.Contains(payload, "192.168.0.0/16") {
+		t.Errorf("policy annotation %q should not apply either conflicting policy's rule", payload)
+	}
+	if cond := route.Status.GetCondition(v1.RouteConditionPolicyReady); cond == nil || !cond.IsFalse() {
+		t.Error("expected PolicyReady to be False when two policies target the same tag")
+	}
+}
+
+func TestReconcilePolicyCatchAllAppliesToUncoveredTags(t *testing.T) {
+	route := &v1.Route{ObjectMeta: metav1.ObjectMeta{Name: "my-route"}}
+	desired := &netv1alpha1.Ingress{}
+	tc := &traffic.Config{Targets: map[string]traffic.RevisionTargets{
+		"":        nil,
+		"staging": nil,
+		"canary":  nil,
+	}}
+	policies := []*v1alpha1.RoutePolicy{
+		{Spec: v1alpha1.RoutePolicySpec{
+			To:   []v1alpha1.RoutePolicyToRef{{Name: "my-route"}},
+			From: []v1alpha1.RoutePolicyFromRef{{CIDR: "10.0.0.0/8"}},
+		}},
+		{Spec: v1alpha1.RoutePolicySpec{
+			To:   []v1alpha1.RoutePolicyToRef{{Name: "my-route", Tag: "canary"}},
+			From: []v1alpha1.RoutePolicyFromRef{{JWTIssuer: "https://issuer.example"}},
+		}},
+	}
+
+	reconcilePolicy(context.Background(), route, tc, policies, desired)
+
+	payload, ok := desired.Annotations[PolicyAnnotationKey]
+	if !ok {
+		t.Fatal("expected a policy annotation to be set")
+	}
+	if !strings.Contains(payload, `"tag":"staging"`) || !strings.Contains(payload, "10.0.0.0/8") {
+		t.Errorf("policy annotation %q should apply the catch-all to the uncovered \"staging\" tag", payload)
+	}
+	if !strings.Contains(payload, "https://issuer.example") {
+		t.Errorf("policy annotation %q should keep canary's own, more specific rule", payload)
+	}
+	if !route.Status.GetCondition(v1.RouteConditionPolicyReady).IsTrue() {
+		t.Error("expected PolicyReady to be True when the catch-all resolves every tag")
+	}
+}
@@ -20,6 +20,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"go.uber.org/zap"
@@ -28,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"knative.dev/networking/pkg/apis/networking"
@@ -36,23 +40,58 @@ import (
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/logging"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/configuration/config"
 	"knative.dev/serving/pkg/reconciler/route/resources"
 	"knative.dev/serving/pkg/reconciler/route/resources/names"
 	"knative.dev/serving/pkg/reconciler/route/traffic"
 )
 
+// RolloutDurationAnnotationKey lets a Route opt into (or tune) a gradual
+// rollout of traffic towards the newest Revision of each of its targets,
+// overriding the cluster-wide default configured via config-features.
+const RolloutDurationAnnotationKey = "serving.knative.dev/rolloutDuration"
+
+// rolloutStepInterval is how often a Route is re-reconciled while a
+// gradual rollout is in progress. It intentionally doesn't vary with the
+// configured rollout duration: a shorter interval just means more, smaller
+// steps, which is the entire point of stepCount() below.
+const rolloutStepInterval = 10 * time.Second
+
 func (c *Reconciler) reconcileIngress(
 	ctx context.Context, r *v1.Route, tc *traffic.Config,
 	tls []netv1alpha1.IngressTLS,
 	ingressClass string,
+	mapped DomainMapped,
+	policies []*v1alpha1.RoutePolicy,
 	acmeChallenges ...netv1alpha1.HTTP01Challenge,
 ) (*netv1alpha1.Ingress, error) {
 	recorder := controller.GetEventRecorder(ctx)
 
+	certResult, err := c.reconcileCertificates(ctx, r, tc)
+	if err != nil {
+		return nil, err
+	}
+	if certResult != nil {
+		tls = append(tls, certResult.TLS...)
+		acmeChallenges = append(acmeChallenges, certResult.HTTP01Challenges...)
+	}
+
 	desired, err := resources.MakeIngress(ctx, r, tc, tls, ingressClass, acmeChallenges...)
 	if err != nil {
 		return nil, err
 	}
+	if certResult != nil && certResult.HTTPOnlyHosts.Len() > 0 {
+		// Certs for these hosts aren't Ready yet; keep them on plain HTTP
+		// instead of redirecting to an HTTPS listener nothing backs, so
+		// the ACME HTTP-01 solver set up via acmeChallenges stays reachable.
+		stripTLSForHosts(desired, certResult.HTTPOnlyHosts)
+	}
+	appendDomainMappingRules(desired, mapped)
+	r.Status.MarkDomainClaimed(mapped.Accepted)
+	r.Status.MarkDomainConflicted(mapped.Conflicting)
+	reconcilePolicy(ctx, r, tc, policies, desired)
+
 	// Get the current rollout state as described by the traffic.
 	curRO := tc.BuildRollout()
 
@@ -75,10 +114,18 @@ func (c *Reconciler) reconcileIngress(
 	} else {
 		// Ingress exists. We need to compute the rollout spec diff.
 		prevRO := deserializeRollout(ctx, ingress.Annotations[networking.RolloutAnnotationKey])
-		effectiveRO := curRO.Step(prevRO)
+		params := traffic.StepParams{TotalSteps: stepCount(rolloutDuration(ctx, r)), StepInterval: rolloutStepInterval}
+		// c.clock is a clock.PassiveClock (k8s.io/utils/clock), swapped for a
+		// fake in tests; NewController wires the real one (clock.RealClock{}).
+		effectiveRO := curRO.Step(prevRO, params, c.clock.Now())
 		// Update the annotation.
 		desired.Annotations[networking.RolloutAnnotationKey] = serializeRollout(ctx, effectiveRO)
-		// TODO(vagababov): apply the Rollout to the ingress spec here.
+		applyRolloutToIngress(effectiveRO, desired)
+		if rolloutInProgress(effectiveRO) {
+			// Requeue through the shared workqueue, as knative.dev/pkg/controller
+			// reconcilers do, rather than a bespoke per-reconciler timer.
+			c.impl.EnqueueKeyAfter(types.NamespacedName{Namespace: r.Namespace, Name: r.Name}, rolloutStepInterval)
+		}
 		if !equality.Semantic.DeepEqual(ingress.Spec, desired.Spec) ||
 			!equality.Semantic.DeepEqual(ingress.Annotations, desired.Annotations) ||
 			!equality.Semantic.DeepEqual(ingress.Labels, desired.Labels) {
@@ -116,10 +163,15 @@ func (c *Reconciler) deleteServices(ctx context.Context, namespace string, servi
 	return nil
 }
 
-func (c *Reconciler) reconcilePlaceholderServices(ctx context.Context, route *v1.Route, targets map[string]traffic.RevisionTargets) ([]*corev1.Service, error) {
+func (c *Reconciler) reconcilePlaceholderServices(ctx context.Context, route *v1.Route, targets map[string]traffic.RevisionTargets, mapped DomainMapped) ([]*corev1.Service, error) {
 	logger := logging.FromContext(ctx)
 	recorder := controller.GetEventRecorder(ctx)
 
+	domainServices, err := c.reconcileDomainMappedServices(ctx, route, mapped)
+	if err != nil {
+		return nil, err
+	}
+
 	existingServices, err := c.getServices(route)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch existing services: %w", err)
@@ -162,12 +214,20 @@ func (c *Reconciler) reconcilePlaceholderServices(ctx context.Context, route *v1
 		services = append(services, service)
 		createdServiceNames.Insert(desiredService.Name)
 	}
+	// The domain-mapped ExternalName services share the Route's
+	// ownership label, so they'd otherwise look like stale tag
+	// placeholders to the cleanup below.
+	for _, svc := range domainServices {
+		createdServiceNames.Insert(svc.Name)
+	}
 
 	// Delete any current services that was no longer desired.
 	if err := c.deleteServices(ctx, ns, existingServiceNames.Difference(createdServiceNames)); err != nil {
 		return nil, err
 	}
 
+	services = append(services, domainServices...)
+
 	// TODO(mattmoor): This is where we'd look at the state of the Service and
 	// reflect any necessary state into the Route.
 	return services, nil
@@ -232,3 +292,115 @@ func deserializeRollout(ctx context.Context, ro string) *traffic.Rollout {
 	}
 	return r
 }
+
+// rolloutDuration returns how long a gradual rollout to the newest Revision
+// of each of r's traffic targets should take, preferring the Route-level
+// override (RolloutDurationAnnotationKey) over the cluster-wide default in
+// config-features. A non-positive duration disables gradual rollout: the
+// full traffic shift happens in a single step, matching prior behavior.
+func rolloutDuration(ctx context.Context, r *v1.Route) time.Duration {
+	if v, ok := r.Annotations[RolloutDurationAnnotationKey]; ok {
+		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return config.FromContextOrDefaults(ctx).Features.RolloutDuration
+}
+
+// rolloutInProgress reports whether any tag in ro still has a revision
+// split that hasn't reached its final percentage.
+func rolloutInProgress(ro *traffic.Rollout) bool {
+	if ro == nil {
+		return false
+	}
+	for _, c := range ro.Configurations {
+		if len(c.Revisions) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// stripTLSForHosts removes httpOnly's hosts from every IngressTLS entry in
+// desired, dropping the entry entirely once it has no hosts left. A host
+// with no IngressTLS entry serves over plain HTTP, which is what a
+// Certificate that isn't Ready yet needs: redirecting it to HTTPS would
+// make the ACME HTTP-01 challenge (served over HTTP) unreachable.
+func stripTLSForHosts(desired *netv1alpha1.Ingress, httpOnly sets.String) {
+	kept := desired.Spec.TLS[:0]
+	for _, tls := range desired.Spec.TLS {
+		hosts := make([]string, 0, len(tls.Hosts))
+		for _, h := range tls.Hosts {
+			if !httpOnly.Has(h) {
+				hosts = append(hosts, h)
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		tls.Hosts = hosts
+		kept = append(kept, tls)
+	}
+	desired.Spec.TLS = kept
+}
+
+// applyRolloutToIngress overwrites the percentages of desired's traffic
+// splits with the (possibly still-converging) ones described by ro, so
+// that a gradual rollout is actually reflected on the wire rather than
+// just recorded in the annotation.
+func applyRolloutToIngress(ro *traffic.Rollout, desired *netv1alpha1.Ingress) {
+	if ro == nil {
+		return
+	}
+	percentFor := rolloutPercentages(ro)
+	if len(percentFor) == 0 {
+		return
+	}
+
+	for i := range desired.Spec.Rules {
+		rule := &desired.Spec.Rules[i]
+		if rule.HTTP == nil {
+			continue
+		}
+		for j := range rule.HTTP.Paths {
+			path := &rule.HTTP.Paths[j]
+			for k := range path.Splits {
+				split := &path.Splits[k]
+				if pct, ok := percentFor[split.ServiceName]; ok {
+					split.Percent = pct
+				}
+			}
+		}
+	}
+}
+
+// rolloutPercentages flattens ro into a map from the per-revision backend
+// service name (which the resources package names after the Revision) to
+// the percentage of traffic it should currently receive.
+func rolloutPercentages(ro *traffic.Rollout) map[string]int {
+	out := make(map[string]int, len(ro.Configurations))
+	for _, c := range ro.Configurations {
+		for _, rr := range c.Revisions {
+			out[names.PrivateService(rr.RevisionName)] = rr.Percent
+		}
+	}
+	return out
+}
+
+// stepCount returns how many discrete steps a rollout lasting `duration`
+// should take, given the fixed cadence at which Routes with an
+// in-progress rollout are re-reconciled. A non-positive duration means no
+// gradual rollout at all.
+func stepCount(duration time.Duration) int {
+	if duration <= 0 {
+		return 0
+	}
+	steps := duration / rolloutStepInterval
+	if duration%rolloutStepInterval != 0 {
+		steps++
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	return int(steps)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	netv1alpha1 "knative.dev/networking/pkg/apis/networking/v1alpha1"
+	"knative.dev/serving/pkg/reconciler/route/traffic"
+)
+
+// splitsFor builds an IngressRule/HTTP/Path/Splits tree with one split per
+// key in percentFor, each seeded with a sentinel percentage so a test can
+// tell whether applyRolloutToIngress actually overwrote it.
+func splitsFor(percentFor map[string]int) *netv1alpha1.Ingress {
+	splits := make([]netv1alpha1.IngressBackendSplit, 0, len(percentFor))
+	for name := range percentFor {
+		splits = append(splits, netv1alpha1.IngressBackendSplit{
+			IngressBackend: netv1alpha1.IngressBackend{ServiceName: name},
+			Percent:        -1, // sentinel: must be overwritten below
+		})
+	}
+	return &netv1alpha1.Ingress{
+		Spec: netv1alpha1.IngressSpec{
+			Rules: []netv1alpha1.IngressRule{{
+				HTTP: &netv1alpha1.HTTPIngressRuleValue{
+					Paths: []netv1alpha1.HTTPIngressPath{{Splits: splits}},
+				},
+			}},
+		},
+	}
+}
+
+func percentsOf(ingress *netv1alpha1.Ingress) map[string]int {
+	out := map[string]int{}
+	for _, split := range ingress.Spec.Rules[0].HTTP.Paths[0].Splits {
+		out[split.ServiceName] = split.Percent
+	}
+	return out
+}
+
+func TestApplyRolloutToIngress(t *testing.T) {
+	ro := &traffic.Rollout{Configurations: []traffic.ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions: []traffic.RevisionRollout{
+			{RevisionName: "cfg-001", Percent: 70},
+			{RevisionName: "cfg-002", Percent: 30},
+		},
+	}}}
+	want := rolloutPercentages(ro)
+	if len(want) != 2 {
+		t.Fatalf("rolloutPercentages returned %d entries, want 2", len(want))
+	}
+
+	desired := splitsFor(want)
+	applyRolloutToIngress(ro, desired)
+
+	if diff := cmp.Diff(want, percentsOf(desired)); diff != "" {
+		t.Errorf("Ingress split percentages (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyRolloutToIngressNilRollout(t *testing.T) {
+	desired := splitsFor(map[string]int{"svc-a": 100})
+	applyRolloutToIngress(nil, desired)
+
+	if got := percentsOf(desired)["svc-a"]; got != -1 {
+		t.Errorf("a nil Rollout should leave existing splits untouched, got Percent = %d", got)
+	}
+}
+
+// TestRolloutReconcilesLandOnIngressSplits drives traffic.Rollout.Step with
+// a fake clock across two simulated reconciles -- the same sequence
+// reconcileIngress runs every rolloutStepInterval while a rollout is in
+// progress -- and asserts the resulting percentages actually land on the
+// Ingress split for the revision, not just on the intermediate Rollout
+// struct.
+func TestRolloutReconcilesLandOnIngressSplits(t *testing.T) {
+	fakeNow := time.Unix(1000, 0)
+	params := traffic.StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	prevRollout := &traffic.Rollout{Configurations: []traffic.ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []traffic.RevisionRollout{{RevisionName: "cfg-001", Percent: 100}},
+	}}}
+	curRollout := &traffic.Rollout{Configurations: []traffic.ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []traffic.RevisionRollout{{RevisionName: "cfg-002", Percent: 100}},
+	}}}
+
+	// Reconcile 1: the target just moved to cfg-002 -- it should take over
+	// fully and immediately (the short-circuit behavior from rollout.go),
+	// and that 100% needs to land on the Ingress split for cfg-002.
+	effectiveRO := curRollout.Step(prevRollout, params, fakeNow)
+	desired := splitsFor(rolloutPercentages(effectiveRO))
+	applyRolloutToIngress(effectiveRO, desired)
+
+	want := rolloutPercentages(effectiveRO)
+	if len(want) != 1 {
+		t.Fatalf("rolloutPercentages returned %d entries, want 1", len(want))
+	}
+	for name, pct := range want {
+		if pct != 100 {
+			t.Errorf("reconcile 1: Percent for %s = %d, want 100", name, pct)
+		}
+	}
+	if diff := cmp.Diff(want, percentsOf(desired)); diff != "" {
+		t.Errorf("reconcile 1 Ingress splits (-want +got):\n%s", diff)
+	}
+
+	// Reconcile 2: three step intervals later, the rollout already
+	// converged in reconcile 1, so it should stay put at 100%.
+	fakeNow = fakeNow.Add(30 * time.Second)
+	effectiveRO2 := curRollout.Step(effectiveRO, params, fakeNow)
+	desired2 := splitsFor(rolloutPercentages(effectiveRO2))
+	applyRolloutToIngress(effectiveRO2, desired2)
+
+	if diff := cmp.Diff(want, percentsOf(desired2)); diff != "" {
+		t.Errorf("reconcile 2 Ingress splits (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import "time"
+
+// Rollout describes the traffic split that is, or should be, in effect for
+// a Route while it gradually shifts weight between revisions. It is
+// serialized into the networking.RolloutAnnotationKey annotation on the
+// Route's Ingress so that it survives across reconciliations.
+type Rollout struct {
+	// Configurations holds one entry per (Configuration, Tag) pair that
+	// currently has traffic assigned to it.
+	Configurations []ConfigurationRollout `json:"configurations,omitempty"`
+}
+
+// ConfigurationRollout is the rollout state for a single tag (the empty
+// string tag represents the default, untagged traffic target).
+type ConfigurationRollout struct {
+	ConfigurationName string `json:"configurationName"`
+	Tag               string `json:"tag,omitempty"`
+
+	// Percent is the total percentage of traffic this tag should
+	// ultimately receive once the rollout completes.
+	Percent int `json:"percent"`
+
+	// Revisions is the current, possibly in-progress, split of Percent
+	// across one or more revisions of ConfigurationName.
+	Revisions []RevisionRollout `json:"revisions"`
+
+	// StepStartTime records when the current step's percentages were
+	// computed, so Step can tell how many step intervals have elapsed.
+	StepStartTime time.Time `json:"stepStartTime,omitempty"`
+
+	// StepsCompleted counts how many discrete steps have already been
+	// applied towards Percent for the newest revision.
+	StepsCompleted int `json:"stepsCompleted,omitempty"`
+}
+
+// RevisionRollout is the traffic percentage assigned to a single revision.
+type RevisionRollout struct {
+	RevisionName string `json:"revisionName"`
+	Percent      int    `json:"percent"`
+}
+
+// StepParams controls how Step paces a rollout. Both fields are derived by
+// the caller from the configured rollout duration D and the fixed interval
+// I at which the Route gets re-reconciled: TotalSteps = ceil(D/I).
+type StepParams struct {
+	// TotalSteps is how many discrete, visible weight changes the
+	// rollout should take in total. Zero (or negative) disables gradual
+	// rollout entirely -- the full percentage moves in one step.
+	TotalSteps int
+
+	// StepInterval is how long each step remains in effect before the
+	// next one may be taken; it's used together with StepStartTime to
+	// figure out how many steps have elapsed.
+	StepInterval time.Duration
+}
+
+// newestRevision returns the revision that Percent is converging towards,
+// i.e. the last entry, by convention the most recently deployed one.
+func (c ConfigurationRollout) newestRevision() (RevisionRollout, bool) {
+	if len(c.Revisions) == 0 {
+		return RevisionRollout{}, false
+	}
+	return c.Revisions[len(c.Revisions)-1], true
+}
+
+func (c ConfigurationRollout) previous(prev *Rollout) (ConfigurationRollout, bool) {
+	if prev == nil {
+		return ConfigurationRollout{}, false
+	}
+	for _, pc := range prev.Configurations {
+		if pc.ConfigurationName == c.ConfigurationName && pc.Tag == c.Tag {
+			return pc, true
+		}
+	}
+	return ConfigurationRollout{}, false
+}
+
+// Step computes the Rollout that should actually be applied to the Ingress
+// for this reconciliation. `r` is the fully-converged desired state (as
+// computed fresh from the Route's traffic targets); `prev` is the state
+// that was in effect as of the last reconciliation (nil if there wasn't
+// one, e.g. on Ingress creation). `now` is used to determine how many step
+// intervals have elapsed since the previous step began.
+//
+// Step never strands traffic: if the desired configuration for a tag has
+// changed since `prev` was computed (a different newest revision, or a
+// different target Percent), that tag short-circuits straight to its fully
+// converged state rather than continuing to interpolate towards a
+// superseded target.
+func (r *Rollout) Step(prev *Rollout, params StepParams, now time.Time) *Rollout {
+	if r == nil {
+		return nil
+	}
+	out := &Rollout{Configurations: make([]ConfigurationRollout, 0, len(r.Configurations))}
+	for _, cur := range r.Configurations {
+		out.Configurations = append(out.Configurations, stepConfiguration(cur, prev, params, now))
+	}
+	return out
+}
+
+func stepConfiguration(cur ConfigurationRollout, prev *Rollout, params StepParams, now time.Time) ConfigurationRollout {
+	newest, ok := cur.newestRevision()
+	if !ok || params.TotalSteps <= 0 {
+		return cur
+	}
+	// Never take more steps than there are percentage points to move --
+	// each step must be visible.
+	totalSteps := params.TotalSteps
+	if totalSteps > cur.Percent {
+		totalSteps = cur.Percent
+	}
+	if totalSteps <= 0 {
+		return cur
+	}
+
+	prevCur, hadPrev := cur.previous(prev)
+	prevNewest, prevHadNewest := prevCur.newestRevision()
+	if !hadPrev || !prevHadNewest || prevNewest.RevisionName != newest.RevisionName || prevCur.Percent != cur.Percent {
+		// Either this is brand new, or the desired destination moved
+		// out from under us (e.g. a new revision was rolled out before
+		// the previous rollout finished, or the route's target Percent
+		// for this tag changed). Don't try to interpolate from a stale
+		// baseline -- jump straight to the final split and start a
+		// fresh rollout clock for any future steps.
+		cur.StepStartTime = now
+		cur.StepsCompleted = 0
+		cur.Revisions = stepRevisions(nil, newest.RevisionName, cur.Percent, totalSteps, 0)
+		return cur
+	}
+
+	// We're continuing a rollout already in progress. Figure out how
+	// many additional steps have elapsed and advance the newest
+	// revision's percentage by that many increments, without
+	// overshooting cur.Percent.
+	elapsedSteps := int(now.Sub(prevCur.StepStartTime) / params.StepInterval)
+	steps := prevCur.StepsCompleted + elapsedSteps
+	if steps > totalSteps {
+		steps = totalSteps
+	}
+
+	// Move the step clock forward by the intervals just counted, so the next
+	// reconcile doesn't count them again on top of StepsCompleted.
+	cur.StepStartTime = prevCur.StepStartTime.Add(time.Duration(elapsedSteps) * params.StepInterval)
+	cur.StepsCompleted = steps
+	if steps >= totalSteps {
+		// Rollout for this tag is done; converge on the final split.
+		cur.Revisions = []RevisionRollout{{RevisionName: newest.RevisionName, Percent: cur.Percent}}
+		return cur
+	}
+
+	cur.Revisions = stepRevisions(prevCur.Revisions, newest.RevisionName, cur.Percent, totalSteps, steps)
+	return cur
+}
+
+// stepRevisions redistributes traffic between the revision(s) carried over
+// from `prevRevisions` and `newest`, moving the newest revision forward by
+// 1/totalSteps of `percent` per completed step. The oldest revision in
+// prevRevisions absorbs whatever's left so percentages always sum to
+// `percent` -- rounding error is intentionally left on the revision being
+// rolled off of, never on the one being rolled onto.
+func stepRevisions(prevRevisions []RevisionRollout, newest string, percent, totalSteps, steps int) []RevisionRollout {
+	if totalSteps <= 0 {
+		return []RevisionRollout{{RevisionName: newest, Percent: percent}}
+	}
+
+	newestPercent := percent * steps / totalSteps
+	remaining := percent - newestPercent
+
+	out := make([]RevisionRollout, 0, len(prevRevisions))
+	for _, rr := range prevRevisions {
+		if rr.RevisionName == newest {
+			continue
+		}
+		out = append(out, rr)
+	}
+
+	if remaining <= 0 {
+		return []RevisionRollout{{RevisionName: newest, Percent: percent}}
+	}
+	if len(out) == 0 {
+		// First step: nothing to roll off of yet, so the entire
+		// remainder stays with whatever the previous newest was.
+		if prev, ok := (ConfigurationRollout{Revisions: prevRevisions}).newestRevision(); ok && prev.RevisionName != newest {
+			out = append(out, RevisionRollout{RevisionName: prev.RevisionName, Percent: remaining})
+		} else {
+			return []RevisionRollout{{RevisionName: newest, Percent: percent}}
+		}
+	} else {
+		// Collapse any carried-over revisions into a single bucket
+		// that shrinks as the newest revision grows.
+		out = []RevisionRollout{{RevisionName: out[0].RevisionName, Percent: remaining}}
+	}
+
+	return append(out, RevisionRollout{RevisionName: newest, Percent: newestPercent})
+}
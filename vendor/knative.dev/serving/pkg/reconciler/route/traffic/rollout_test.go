@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRolloutStepFresh(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cur := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}},
+	}}}
+	params := StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	got := cur.Step(nil, params, now)
+
+	// There's no prior state at all (e.g. the Ingress is being created for
+	// the first time), so there's nothing to roll over from -- the newest
+	// revision gets its full share immediately.
+	want := []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}}
+	if diff := cmp.Diff(want, got.Configurations[0].Revisions); diff != "" {
+		t.Errorf("first-ever step should put 100%% on the newest revision (-want +got):\n%s", diff)
+	}
+	if got.Configurations[0].StepStartTime != now {
+		t.Errorf("StepStartTime = %v, want %v", got.Configurations[0].StepStartTime, now)
+	}
+}
+
+func TestRolloutStepTargetChangeShortCircuits(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	// cfg-001 is fully serving traffic and the Route's target just moved to
+	// cfg-002 -- the common case the whole feature exists for. The new
+	// target should take over immediately rather than stranding traffic on
+	// cfg-001 while it interpolates.
+	prev := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-001", Percent: 100}},
+	}}}
+	cur := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}},
+	}}}
+	params := StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	got := cur.Step(prev, params, now)
+
+	want := []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}}
+	if diff := cmp.Diff(want, got.Configurations[0].Revisions); diff != "" {
+		t.Errorf("a target change should short-circuit straight to the new target (-want +got):\n%s", diff)
+	}
+	if got.Configurations[0].StepsCompleted != 0 {
+		t.Errorf("StepsCompleted = %d, want 0", got.Configurations[0].StepsCompleted)
+	}
+	if got.Configurations[0].StepStartTime != now {
+		t.Errorf("StepStartTime = %v, want %v", got.Configurations[0].StepStartTime, now)
+	}
+}
+
+func TestRolloutStepProgresses(t *testing.T) {
+	start := time.Unix(1000, 0)
+	params := StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	prev := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-001", Percent: 100}, {RevisionName: "cfg-002", Percent: 0}},
+		StepStartTime:     start,
+		StepsCompleted:    0,
+	}}}
+	cur := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}},
+	}}}
+
+	// Three step intervals have elapsed.
+	now := start.Add(30 * time.Second)
+	got := cur.Step(prev, params, now)
+
+	want := []RevisionRollout{{RevisionName: "cfg-001", Percent: 70}, {RevisionName: "cfg-002", Percent: 30}}
+	if diff := cmp.Diff(want, got.Configurations[0].Revisions); diff != "" {
+		t.Errorf("unexpected split after 3 steps (-want +got):\n%s", diff)
+	}
+	if got.Configurations[0].StepsCompleted != 3 {
+		t.Errorf("StepsCompleted = %d, want 3", got.Configurations[0].StepsCompleted)
+	}
+}
+
+func TestRolloutStepCompletes(t *testing.T) {
+	start := time.Unix(1000, 0)
+	params := StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	prev := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-001", Percent: 10}, {RevisionName: "cfg-002", Percent: 90}},
+		StepStartTime:     start,
+		StepsCompleted:    9,
+	}}}
+	cur := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}},
+	}}}
+
+	now := start.Add(200 * time.Second)
+	got := cur.Step(prev, params, now)
+
+	want := []RevisionRollout{{RevisionName: "cfg-002", Percent: 100}}
+	if diff := cmp.Diff(want, got.Configurations[0].Revisions); diff != "" {
+		t.Errorf("rollout should have converged (-want +got):\n%s", diff)
+	}
+	if rolloutInProgressForTest(got) {
+		t.Error("expected rollout to be complete")
+	}
+}
+
+func TestRolloutStepSupersededTarget(t *testing.T) {
+	start := time.Unix(1000, 0)
+	params := StepParams{TotalSteps: 10, StepInterval: 10 * time.Second}
+
+	// Midway through a rollout to cfg-002, a new revision cfg-003 is deployed.
+	prev := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-001", Percent: 50}, {RevisionName: "cfg-002", Percent: 50}},
+		StepStartTime:     start,
+		StepsCompleted:    5,
+	}}}
+	cur := &Rollout{Configurations: []ConfigurationRollout{{
+		ConfigurationName: "cfg",
+		Percent:           100,
+		Revisions:         []RevisionRollout{{RevisionName: "cfg-003", Percent: 100}},
+	}}}
+
+	now := start.Add(20 * time.Second)
+	got := cur.Step(prev, params, now)
+
+	// The superseding revision takes over fully and immediately -- no
+	// traffic should be left mislabeled on cfg-001 or cfg-002.
+	want := []RevisionRollout{{RevisionName: "cfg-003", Percent: 100}}
+	if diff := cmp.Diff(want, got.Configurations[0].Revisions); diff != "" {
+		t.Errorf("a new revision superseding an in-progress rollout should take over immediately (-want +got):\n%s", diff)
+	}
+	if got.Configurations[0].StepsCompleted != 0 {
+		t.Errorf("StepsCompleted = %d, want 0 (fresh rollout)", got.Configurations[0].StepsCompleted)
+	}
+	if got.Configurations[0].StepStartTime != now {
+		t.Errorf("StepStartTime = %v, want %v (restarted)", got.Configurations[0].StepStartTime, now)
+	}
+}
+
+func rolloutInProgressForTest(ro *Rollout) bool {
+	for _, c := range ro.Configurations {
+		if len(c.Revisions) > 1 {
+			return true
+		}
+	}
+	return false
+}